@@ -1,13 +1,18 @@
 package service
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
+	"os/user"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -27,6 +32,29 @@ func init() {
 	}
 }
 
+// Option keys for the optional svlogd-backed log/ companion service.
+const (
+	optionLogDir     = "LogDir"
+	optionLogUser    = "LogUser"
+	optionLogSize    = "LogSize"
+	optionLogNum     = "LogNum"
+	optionLogTimeout = "LogTimeout"
+)
+
+// Option keys for chpst-based privilege dropping and resource limits.
+const (
+	optionLimitNOFILE = "LimitNOFILE"
+	optionLimitNPROC  = "LimitNPROC"
+	optionLimitCORE   = "LimitCORE"
+	optionNice        = "Nice"
+	optionChroot      = "Chroot"
+)
+
+// optionInstallReadyTimeout overrides installReadyTimeoutDefault, the
+// duration Install waits for runsvdir to pick up a newly installed service.
+// The value is parsed with time.ParseDuration, e.g. "30s".
+const optionInstallReadyTimeout = "InstallReadyTimeout"
+
 func isRunit() bool {
 	if _, err := exec.LookPath("runsvdir"); err == nil {
 		return true
@@ -114,24 +142,352 @@ func (r *runit) Install() error {
 		return err
 	}
 
+	chpstArgs, err := r.chpstArgs()
+	if err != nil {
+		return err
+	}
+
 	var to = &struct {
 		*Config
-		Path string
+		Path      string
+		ChpstArgs string
 	}{
 		r.Config,
 		path,
+		chpstArgs,
 	}
 
 	err = r.template().Execute(f, to)
 	if err != nil {
 		return err
 	}
+
+	if err = r.installLog(runItPath); err != nil {
+		return err
+	}
+
 	err = os.Symlink(runItPath, r.svcDir)
 	if err != nil {
 		return err
 	}
-	time.Sleep(6000 * time.Millisecond)
-	return nil
+	return r.waitUntilSupervised()
+}
+
+const installReadyTimeoutDefault = 10 * time.Second
+
+const installReadyPollInterval = 100 * time.Millisecond
+
+// waitUntilSupervised polls `sv check` until runsvdir picks up r.svcDir or
+// InstallReadyTimeout elapses.
+func (r *runit) waitUntilSupervised() error {
+	timeout := installReadyTimeoutDefault
+	if val := r.Option.string(optionInstallReadyTimeout, ""); val != "" {
+		if d, err := time.ParseDuration(val); err == nil {
+			timeout = d
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if exitCode, _, err := runWithOutput("sv", "check", r.svcDir); err == nil && exitCode == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for runsvdir to supervise %s", timeout, r.svcDir)
+		}
+		time.Sleep(installReadyPollInterval)
+	}
+}
+
+// installLog provisions a companion svlogd-backed log/ service. No-op
+// unless LogDir is set.
+func (r *runit) installLog(runItPath string) error {
+	logDir := r.logDir()
+	if logDir == "" {
+		return nil
+	}
+	logUser := r.Option.string(optionLogUser, "")
+
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+	if err := chownToUser(logDir, logUser); err != nil {
+		return err
+	}
+
+	logSvcPath := filepath.Join(runItPath, "log")
+	if err := os.MkdirAll(logSvcPath, 0755); err != nil {
+		return err
+	}
+	runPath := filepath.Join(logSvcPath, "run")
+	f, err := os.Create(runPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = os.Chmod(runPath, 0755); err != nil {
+		return err
+	}
+
+	var to = &struct {
+		LogDir  string
+		LogUser string
+	}{
+		logDir,
+		logUser,
+	}
+	if err = template.Must(template.New("").Parse(runItLogScript)).Execute(f, to); err != nil {
+		return err
+	}
+
+	if err := r.writeLogConfig(logDir); err != nil {
+		return err
+	}
+	return chownToUser(filepath.Join(logDir, "config"), logUser)
+}
+
+// chownToUser chowns path to name's uid/gid, so svlogd (dropped to that
+// user via chpst) can write into a directory it doesn't own. No-op if name
+// is "" or path doesn't exist (writeLogConfig skips the config file when no
+// rotation options are set).
+func chownToUser(path, name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		return err
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return err
+	}
+	return os.Chown(path, uid, gid)
+}
+
+// writeLogConfig writes svlogd's one-directive-per-line config (svlogd(8)).
+func (r *runit) writeLogConfig(logDir string) error {
+	var lines []string
+	if size := r.Option.string(optionLogSize, ""); size != "" {
+		lines = append(lines, "s"+size)
+	}
+	if num := r.Option.string(optionLogNum, ""); num != "" {
+		lines = append(lines, "n"+num)
+	}
+	if timeout := r.Option.string(optionLogTimeout, ""); timeout != "" {
+		lines = append(lines, "t"+timeout)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(logDir, "config"), []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// LogOptions controls how Logs reads back a service's output.
+type LogOptions struct {
+	// Follow keeps the channel open and streams new lines as they are
+	// written, instead of closing once the backlog has been sent.
+	Follow bool
+	// Since, if non-zero, drops lines timestamped before it. Only lines
+	// svlogd wrote with a parseable timestamp are filtered this way.
+	Since time.Time
+	// Tail, if > 0, limits the initial backlog to the last N lines.
+	Tail int
+}
+
+// LogLine is a single line of service output read back from disk.
+type LogLine struct {
+	// Time is the timestamp svlogd prefixed the line with, in its raw
+	// "YYYY-MM-DD_HH:MM:SS.ffffff" form, or "" if the line had none.
+	Time string
+	// Text is the line with the svlogd timestamp prefix stripped.
+	Text string
+	// Err is set instead of Time/Text if reading the log failed.
+	Err error
+}
+
+const svlogdTimeLayout = "2006-01-02_15:04:05.000000"
+
+func (r *runit) logDir() string {
+	return r.Option.string(optionLogDir, "")
+}
+
+// Logs tails svlogd's "current" file, plus rotated "@....s" files for backlog.
+func (r *runit) Logs(ctx context.Context, opts LogOptions) (<-chan LogLine, error) {
+	logDir := r.logDir()
+	if logDir == "" {
+		return nil, errors.New("service has no log directory configured")
+	}
+
+	out := make(chan LogLine)
+	go r.tailLogs(ctx, logDir, opts, out)
+	return out, nil
+}
+
+func (r *runit) tailLogs(ctx context.Context, logDir string, opts LogOptions, out chan<- LogLine) {
+	defer close(out)
+
+	files, err := svLogFiles(logDir)
+	if err != nil {
+		sendLogLine(ctx, out, LogLine{Err: err})
+		return
+	}
+
+	// offset is seeded from exactly what readLogLines consumed out of
+	// "current", so nothing written between then and here is skipped.
+	backlog, offset, err := readLogLines(files, opts)
+	if err != nil {
+		sendLogLine(ctx, out, LogLine{Err: err})
+		return
+	}
+	for _, ll := range backlog {
+		if !sendLogLine(ctx, out, ll) {
+			return
+		}
+	}
+
+	if !opts.Follow {
+		return
+	}
+
+	current := filepath.Join(logDir, "current")
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var lines []string
+			offset, lines, err = readLinesFrom(current, offset)
+			if err != nil {
+				continue
+			}
+			for _, line := range lines {
+				ll, _ := parseSvlogdLine(line)
+				if !sendLogLine(ctx, out, ll) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// svLogFiles lists rotated log files in rotation order, followed by "current".
+func svLogFiles(logDir string) ([]string, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var rotated []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, "@") && strings.HasSuffix(name, ".s") {
+			rotated = append(rotated, name)
+		}
+	}
+	sort.Strings(rotated)
+
+	files := make([]string, 0, len(rotated)+1)
+	for _, name := range rotated {
+		files = append(files, filepath.Join(logDir, name))
+	}
+	if _, err := os.Stat(filepath.Join(logDir, "current")); err == nil {
+		files = append(files, filepath.Join(logDir, "current"))
+	}
+	return files, nil
+}
+
+// readLogLines applies Since/Tail from opts and also returns the offset
+// consumed from "current" (0 if absent), so a follow loop can pick up there.
+func readLogLines(files []string, opts LogOptions) ([]LogLine, int64, error) {
+	var all []LogLine
+	var currentOffset int64
+	for _, path := range files {
+		offset, lines, err := readLinesFrom(path, 0)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, line := range lines {
+			ll, t := parseSvlogdLine(line)
+			if !opts.Since.IsZero() && !t.IsZero() && t.Before(opts.Since) {
+				continue
+			}
+			all = append(all, ll)
+		}
+		if filepath.Base(path) == "current" {
+			currentOffset = offset
+		}
+	}
+
+	if opts.Tail > 0 && len(all) > opts.Tail {
+		all = all[len(all)-opts.Tail:]
+	}
+	return all, currentOffset, nil
+}
+
+// readLinesFrom returns path's complete lines after offset and the offset
+// just past the last newline found, holding back any trailing partial line
+// (svlogd still mid-write) for the next call. Resets to 0 if path shrank.
+func readLinesFrom(path string, offset int64) (int64, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return offset, nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return offset, nil, err
+	}
+	if fi.Size() < offset {
+		offset = 0
+	}
+	if fi.Size() == offset {
+		return offset, nil, nil
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return offset, nil, err
+	}
+
+	last := bytes.LastIndexByte(data, '\n')
+	if last < 0 {
+		return offset, nil, nil
+	}
+	return offset + int64(last) + 1, strings.Split(string(data[:last]), "\n"), nil
+}
+
+// parseSvlogdLine splits a svlogd -tt line into its timestamp and message.
+func parseSvlogdLine(line string) (LogLine, time.Time) {
+	if fields := strings.SplitN(line, " ", 2); len(fields) == 2 {
+		if t, err := time.Parse(svlogdTimeLayout, fields[0]); err == nil {
+			return LogLine{Time: fields[0], Text: fields[1]}, t
+		}
+	}
+	return LogLine{Text: line}, time.Time{}
+}
+
+func sendLogLine(ctx context.Context, out chan<- LogLine, ll LogLine) bool {
+	select {
+	case out <- ll:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 func (r *runit) Uninstall() error {
@@ -173,33 +529,89 @@ func (r *runit) Run() (err error) {
 }
 
 func (r *runit) Status() (Status, error) {
-	_, out, err := runWithOutput("sv", "status", r.svcDir)
+	info, err := r.StatusDetail()
 	if err != nil {
 		return StatusUnknown, err
 	}
-	if strings.Contains(out, "run") {
+	switch info.State {
+	case "run":
 		return StatusRunning, nil
-	} else if strings.Contains(out, "down") {
+	case "down", "finish":
 		return StatusStopped, nil
 	}
 	return StatusUnknown, nil
 }
 
 func (r *runit) GetPid() (uint32, error) {
-	exitCode, out, err := runWithOutput("sv", "status", r.svcDir)
-	if exitCode == 0 && err != nil {
+	info, err := r.StatusDetail()
+	if err != nil {
 		return 0, err
 	}
-	re := regexp.MustCompile(`pid ([0-9]+)`)
-	matches := re.FindStringSubmatch(out)
-	if len(matches) != 2 {
+	if info.PID == 0 {
 		return 0, errors.New("failed to match pid info")
 	}
-	pid, err := strconv.ParseUint(matches[1], 10, 32)
+	return info.PID, nil
+}
+
+// StatusInfo is the full state `sv status` reports for a service.
+type StatusInfo struct {
+	// State is "run", "down", or "finish".
+	State string
+	// PID is the supervised process's PID, or 0 if it isn't running.
+	PID uint32
+	// Seconds is how long the service has been in State.
+	Seconds int
+	// WantUp is true if sv status reports "want up".
+	WantUp bool
+	// WantDown is true if sv status reports "want down".
+	WantDown bool
+	// NormallyUp is true unless a "down" file is present in the service
+	// directory, i.e. whether runsv starts the service by default.
+	NormallyUp bool
+}
+
+var (
+	svStatusStateRe   = regexp.MustCompile(`^(run|down|finish)`)
+	svStatusPidRe     = regexp.MustCompile(`pid ([0-9]+)`)
+	svStatusSecondsRe = regexp.MustCompile(`\b([0-9]+)s\b`)
+)
+
+func (r *runit) StatusDetail() (StatusInfo, error) {
+	_, out, err := runWithOutput("sv", "status", r.svcDir)
 	if err != nil {
-		return 0, err
+		return StatusInfo{}, err
 	}
-	return uint32(pid), nil
+	return parseSvStatus(out)
+}
+
+// parseSvStatus parses a line of `sv status` output, e.g.
+// "run: /etc/service/foo: (pid 1234) 56s", into a StatusInfo. sv only prints
+// a deviation clause ("normally down") when a "down" file is present, so
+// NormallyUp is the absence of that clause rather than the presence of one.
+func parseSvStatus(out string) (StatusInfo, error) {
+	m := svStatusStateRe.FindStringSubmatch(out)
+	if m == nil {
+		return StatusInfo{}, fmt.Errorf("failed to parse sv status output: %q", out)
+	}
+	info := StatusInfo{
+		State:      m[1],
+		NormallyUp: !strings.Contains(out, "normally down"),
+		WantUp:     strings.Contains(out, "want up"),
+		WantDown:   strings.Contains(out, "want down"),
+	}
+
+	if m := svStatusPidRe.FindStringSubmatch(out); m != nil {
+		if pid, err := strconv.ParseUint(m[1], 10, 32); err == nil {
+			info.PID = uint32(pid)
+		}
+	}
+	if m := svStatusSecondsRe.FindStringSubmatch(out); m != nil {
+		if secs, err := strconv.Atoi(m[1]); err == nil {
+			info.Seconds = secs
+		}
+	}
+
+	return info, nil
 }
 
 func (r *runit) Start() error {
@@ -214,6 +626,122 @@ func (r *runit) Restart() error {
 	return run("sv", "restart", r.svcDir)
 }
 
+func (r *runit) Reload() error {
+	sig, err := parseSignalName(r.Option.string(optionReloadSignal, "SIGHUP"))
+	if err != nil {
+		return err
+	}
+	return r.Signal(sig)
+}
+
+// Signal translates sig to the matching `sv` control command (see sv(8))
+// and runs it against r.svcDir.
+func (r *runit) Signal(sig syscall.Signal) error {
+	cmd, err := svSignalCommand(sig)
+	if err != nil {
+		return err
+	}
+	return run("sv", cmd, r.svcDir)
+}
+
+func svSignalCommand(sig syscall.Signal) (string, error) {
+	switch sig {
+	case syscall.SIGHUP:
+		return "hup", nil
+	case syscall.SIGALRM:
+		return "alarm", nil
+	case syscall.SIGINT:
+		return "interrupt", nil
+	case syscall.SIGQUIT:
+		return "quit", nil
+	case syscall.SIGUSR1:
+		return "1", nil
+	case syscall.SIGUSR2:
+		return "2", nil
+	case syscall.SIGTERM:
+		return "term", nil
+	case syscall.SIGKILL:
+		return "kill", nil
+	case syscall.SIGCONT:
+		return "cont", nil
+	default:
+		return "", fmt.Errorf("unsupported signal for runit: %v", sig)
+	}
+}
+
+// parseSignalName maps a signal name, with or without the "SIG" prefix, to
+// a syscall.Signal.
+func parseSignalName(name string) (syscall.Signal, error) {
+	switch strings.ToUpper(strings.TrimPrefix(name, "SIG")) {
+	case "HUP":
+		return syscall.SIGHUP, nil
+	case "ALRM":
+		return syscall.SIGALRM, nil
+	case "INT":
+		return syscall.SIGINT, nil
+	case "QUIT":
+		return syscall.SIGQUIT, nil
+	case "USR1":
+		return syscall.SIGUSR1, nil
+	case "USR2":
+		return syscall.SIGUSR2, nil
+	case "TERM":
+		return syscall.SIGTERM, nil
+	case "KILL":
+		return syscall.SIGKILL, nil
+	case "CONT":
+		return syscall.SIGCONT, nil
+	default:
+		return 0, fmt.Errorf("unsupported reload signal: %s", name)
+	}
+}
+
+// chpstArgs builds the chpst(8) flags for the configured UserName/Group and
+// resource limits. It returns "" when none are set, so the run script falls
+// back to exec'ing the command directly.
+func (r *runit) chpstArgs() (string, error) {
+	return buildChpstArgs(
+		r.UserName, r.Group,
+		r.Option.string(optionLimitNOFILE, ""),
+		r.Option.string(optionLimitNPROC, ""),
+		r.Option.string(optionLimitCORE, ""),
+		r.Option.string(optionNice, ""),
+		r.Option.string(optionChroot, ""),
+	)
+}
+
+func buildChpstArgs(user, group, limitNOFILE, limitNPROC, limitCORE, nice, chroot string) (string, error) {
+	var args []string
+
+	if group != "" && user == "" {
+		return "", errors.New("runit: Group requires UserName to also be set (chpst has no group-only flag)")
+	}
+	if user != "" {
+		if group != "" {
+			args = append(args, "-u", user+":"+group)
+		} else {
+			args = append(args, "-u", user)
+		}
+	}
+	if limitNOFILE != "" {
+		args = append(args, "-o", limitNOFILE)
+	}
+	if limitNPROC != "" {
+		args = append(args, "-p", limitNPROC)
+	}
+	if limitCORE != "" {
+		args = append(args, "-c", limitCORE)
+	}
+	if nice != "" {
+		args = append(args, "-n", nice)
+	}
+	if chroot != "" {
+		args = append(args, "-/", chroot)
+	}
+
+	return strings.Join(args, " "), nil
+}
+
 func (r *runit) runAction(action string) error {
 	return r.run(action, r.Name)
 }
@@ -225,5 +753,11 @@ func (r *runit) run(action string, args ...string) error {
 const runItScript = `#!/bin/sh
 exec 2>&1
 cd {{.WorkingDirectory}}
-exec {{.Path|cmdEscape}} {{- if .Arguments }} {{range .Arguments}}{{.}} {{end}} {{- end }}
+{{if .ChpstArgs}}exec chpst {{.ChpstArgs}}{{else}}exec{{end}} {{.Path|cmdEscape}} {{- if .Arguments }} {{range .Arguments}}{{.}} {{end}} {{- end }}
+`
+
+// runItLogScript is the run script for the companion log/ service.
+const runItLogScript = `#!/bin/sh
+exec 2>&1
+{{if .LogUser}}exec chpst -u {{.LogUser}} svlogd -tt {{.LogDir}}{{else}}exec svlogd -tt {{.LogDir}}{{end}}
 `
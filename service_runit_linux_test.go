@@ -0,0 +1,288 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestParseSvlogdLine(t *testing.T) {
+	cases := []struct {
+		name     string
+		line     string
+		wantText string
+		wantTime string
+	}{
+		{
+			name:     "timestamped",
+			line:     "2026-07-29_18:04:05.123456 listening on :8080",
+			wantText: "listening on :8080",
+			wantTime: "2026-07-29_18:04:05.123456",
+		},
+		{
+			name:     "no timestamp",
+			line:     "listening on :8080",
+			wantText: "listening on :8080",
+			wantTime: "",
+		},
+		{
+			name:     "malformed timestamp",
+			line:     "not-a-time rest of line",
+			wantText: "not-a-time rest of line",
+			wantTime: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ll, ts := parseSvlogdLine(c.line)
+			if ll.Text != c.wantText {
+				t.Errorf("Text = %q, want %q", ll.Text, c.wantText)
+			}
+			if ll.Time != c.wantTime {
+				t.Errorf("Time = %q, want %q", ll.Time, c.wantTime)
+			}
+			if c.wantTime == "" && !ts.IsZero() {
+				t.Errorf("parsed time = %v, want zero", ts)
+			}
+		})
+	}
+}
+
+func TestParseSvStatus(t *testing.T) {
+	cases := []struct {
+		name           string
+		out            string
+		wantState      string
+		wantPID        uint32
+		wantSeconds    int
+		wantNormallyUp bool
+		wantWantUp     bool
+		wantWantDown   bool
+		wantErr        bool
+	}{
+		{
+			name:           "running, default",
+			out:            "run: /etc/service/foo: (pid 1234) 56s\n",
+			wantState:      "run",
+			wantPID:        1234,
+			wantSeconds:    56,
+			wantNormallyUp: true,
+			wantWantUp:     false,
+		},
+		{
+			name:           "down, normally down, want up",
+			out:            "down: /etc/service/foo: 3s, normally down, want up\n",
+			wantState:      "down",
+			wantSeconds:    3,
+			wantNormallyUp: false,
+			wantWantUp:     true,
+		},
+		{
+			name:           "running but wants down",
+			out:            "run: /etc/service/foo: (pid 5678) 12s, want down\n",
+			wantState:      "run",
+			wantPID:        5678,
+			wantSeconds:    12,
+			wantNormallyUp: true,
+			wantWantDown:   true,
+		},
+		{
+			name:    "unparseable",
+			out:     "sv: unable to chdir: /etc/service/foo: file does not exist\n",
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			info, err := parseSvStatus(c.out)
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.State != c.wantState {
+				t.Errorf("State = %q, want %q", info.State, c.wantState)
+			}
+			if info.PID != c.wantPID {
+				t.Errorf("PID = %d, want %d", info.PID, c.wantPID)
+			}
+			if info.Seconds != c.wantSeconds {
+				t.Errorf("Seconds = %d, want %d", info.Seconds, c.wantSeconds)
+			}
+			if info.NormallyUp != c.wantNormallyUp {
+				t.Errorf("NormallyUp = %v, want %v", info.NormallyUp, c.wantNormallyUp)
+			}
+			if info.WantUp != c.wantWantUp {
+				t.Errorf("WantUp = %v, want %v", info.WantUp, c.wantWantUp)
+			}
+			if info.WantDown != c.wantWantDown {
+				t.Errorf("WantDown = %v, want %v", info.WantDown, c.wantWantDown)
+			}
+		})
+	}
+}
+
+func TestBuildChpstArgs(t *testing.T) {
+	cases := []struct {
+		name    string
+		user    string
+		group   string
+		want    string
+		wantErr bool
+	}{
+		{name: "none", want: ""},
+		{name: "user only", user: "svc", want: "-u svc"},
+		{name: "user and group", user: "svc", group: "svcgrp", want: "-u svc:svcgrp"},
+		{name: "group without user", group: "svcgrp", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := buildChpstArgs(c.user, c.group, "", "", "", "", "")
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != c.want {
+				t.Errorf("buildChpstArgs() = %q, want %q", got, c.want)
+			}
+		})
+	}
+
+	got, err := buildChpstArgs("svc", "", "1024", "64", "0", "10", "/srv/chroot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "-u svc -o 1024 -p 64 -c 0 -n 10 -/ /srv/chroot"
+	if got != want {
+		t.Errorf("buildChpstArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestSvSignalCommand(t *testing.T) {
+	cases := []struct {
+		sig     syscall.Signal
+		want    string
+		wantErr bool
+	}{
+		{sig: syscall.SIGHUP, want: "hup"},
+		{sig: syscall.SIGUSR1, want: "1"},
+		{sig: syscall.SIGUSR2, want: "2"},
+		{sig: syscall.SIGKILL, want: "kill"},
+		{sig: syscall.SIGWINCH, wantErr: true},
+	}
+
+	for _, c := range cases {
+		got, err := svSignalCommand(c.sig)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("svSignalCommand(%v): expected error, got nil", c.sig)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("svSignalCommand(%v): unexpected error: %v", c.sig, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("svSignalCommand(%v) = %q, want %q", c.sig, got, c.want)
+		}
+	}
+}
+
+func TestParseSignalName(t *testing.T) {
+	cases := []struct {
+		name    string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{name: "HUP", want: syscall.SIGHUP},
+		{name: "SIGHUP", want: syscall.SIGHUP},
+		{name: "usr1", want: syscall.SIGUSR1},
+		{name: "sigterm", want: syscall.SIGTERM},
+		{name: "BOGUS", wantErr: true},
+	}
+
+	for _, c := range cases {
+		got, err := parseSignalName(c.name)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseSignalName(%q): expected error, got nil", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSignalName(%q): unexpected error: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("parseSignalName(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestReadLinesFrom(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "current")
+
+	if err := os.WriteFile(path, []byte("2026-07-29_18:00:00.000000 first\n2026-07-29_18:00:01.000000 second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	offset, lines, err := readLinesFrom(path, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %v", len(lines), lines)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("2026-07-29_18:00:02.000000 thi"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	offset2, lines, err := readLinesFrom(path, offset)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 0 {
+		t.Fatalf("got %d lines for partial write, want 0: %v", len(lines), lines)
+	}
+	if offset2 != offset {
+		t.Fatalf("offset advanced past a partial line: got %d, want %d", offset2, offset)
+	}
+
+	f, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("rd\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	_, lines, err = readLinesFrom(path, offset2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 1 || lines[0] != "2026-07-29_18:00:02.000000 third" {
+		t.Fatalf("got %v, want completed third line", lines)
+	}
+}